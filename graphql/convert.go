@@ -0,0 +1,33 @@
+package graphql
+
+import (
+	"fmt"
+
+	"google.golang.org/appengine/datastore"
+
+	"github.com/heshuaidi/real-world-plantuml/graphql/model"
+	"github.com/heshuaidi/real-world-plantuml/indexer"
+)
+
+// toModel projects an indexer.Uml loaded from the given key into its
+// GraphQL representation. Heavy fields are only populated when requested,
+// so a query that doesn't select svg/pngBase64/ascii never has to carry
+// them across the resolver boundary.
+func toModel(key *datastore.Key, uml *indexer.Uml, hydrate model.HydrateFields) *model.Uml {
+	out := &model.Uml{
+		ID:          fmt.Sprintf("%d", key.IntID()),
+		GitHubUrl:   uml.GitHubUrl,
+		Source:      uml.Source,
+		DiagramType: model.FromIndexer(uml.DiagramType),
+	}
+	if hydrate.Svg {
+		out.Svg = &uml.Svg
+	}
+	if hydrate.PngBase64 {
+		out.PngBase64 = &uml.PngBase64
+	}
+	if hydrate.Ascii {
+		out.Ascii = &uml.Ascii
+	}
+	return out
+}
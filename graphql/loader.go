@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/appengine/datastore"
+
+	"github.com/heshuaidi/real-world-plantuml/indexer"
+)
+
+// batchWindow is how long Load waits for sibling lookups issued by other
+// fields in the same GraphQL query to join the current batch before it is
+// dispatched as a single GetMulti.
+const batchWindow = time.Millisecond
+
+// UmlLoader batches concurrent-looking Uml lookups issued while resolving a
+// single GraphQL query into one datastore.GetMulti call, so e.g. a query
+// that aliases the uml(id) root field N times costs one round trip instead
+// of N.
+//
+// It is not safe for use across requests: a fresh loader is created per
+// GraphQL request by withUmlLoader and attached to the request context.
+type UmlLoader struct {
+	ctx context.Context
+
+	mu      sync.Mutex
+	pending []*datastore.Key
+	waiters []chan umlResult
+	wait    *sync.Once
+}
+
+type umlResult struct {
+	uml *indexer.Uml
+	err error
+}
+
+func NewUmlLoader(ctx context.Context) *UmlLoader {
+	return &UmlLoader{ctx: ctx, wait: &sync.Once{}}
+}
+
+// Load queues key for the next batch and returns the Uml once the batch
+// has been dispatched. Multiple goroutines calling Load before the batch
+// fires are coalesced into a single GetMulti.
+func (l *UmlLoader) Load(key *datastore.Key) (*indexer.Uml, error) {
+	ch := make(chan umlResult, 1)
+
+	l.mu.Lock()
+	l.pending = append(l.pending, key)
+	l.waiters = append(l.waiters, ch)
+	once := l.wait
+	l.mu.Unlock()
+
+	// Dispatch on the first waiter of each batch, after a short window
+	// that lets sibling Load calls from other resolvers join in.
+	once.Do(func() {
+		go func() {
+			time.Sleep(batchWindow)
+			l.dispatch()
+		}()
+	})
+
+	res := <-ch
+	return res.uml, res.err
+}
+
+func (l *UmlLoader) dispatch() {
+	l.mu.Lock()
+	keys := l.pending
+	waiters := l.waiters
+	l.pending = nil
+	l.waiters = nil
+	l.wait = &sync.Once{}
+	l.mu.Unlock()
+
+	umls := make([]indexer.Uml, len(keys))
+	err := datastore.GetMulti(l.ctx, keys, umls)
+
+	for i, ch := range waiters {
+		if multiErr, ok := err.(datastore.MultiError); ok {
+			ch <- umlResult{uml: &umls[i], err: multiErr[i]}
+			continue
+		}
+		ch <- umlResult{uml: &umls[i], err: err}
+	}
+}
+
+type umlLoaderContextKey struct{}
+
+// withUmlLoader attaches a fresh UmlLoader to ctx, scoped to a single
+// GraphQL request. NewHandler installs this before handing the request to
+// the schema executor.
+func withUmlLoader(ctx context.Context) context.Context {
+	return context.WithValue(ctx, umlLoaderContextKey{}, NewUmlLoader(ctx))
+}
+
+// umlLoaderFromContext retrieves the loader withUmlLoader attached to ctx.
+// It returns nil if none was attached; Resolver.Uml then loads through a
+// fresh single-use UmlLoader instead.
+func umlLoaderFromContext(ctx context.Context) *UmlLoader {
+	loader, _ := ctx.Value(umlLoaderContextKey{}).(*UmlLoader)
+	return loader
+}
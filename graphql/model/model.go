@@ -0,0 +1,101 @@
+// Package model holds the GraphQL-facing types resolved against
+// schema.graphqls. It is kept free of datastore/search dependencies so the
+// graphql package stays the only thing that knows how to translate between
+// the indexer's domain types and the API's wire types.
+package model
+
+import (
+	"github.com/heshuaidi/real-world-plantuml/indexer"
+)
+
+// DiagramType mirrors indexer.DiagramType as a GraphQL enum.
+type DiagramType string
+
+const (
+	DiagramTypeSequence  DiagramType = "SEQUENCE"
+	DiagramTypeUsecase   DiagramType = "USECASE"
+	DiagramTypeClass     DiagramType = "CLASS"
+	DiagramTypeActivity  DiagramType = "ACTIVITY"
+	DiagramTypeComponent DiagramType = "COMPONENT"
+	DiagramTypeState     DiagramType = "STATE"
+	DiagramTypeUnknown   DiagramType = "UNKNOWN"
+)
+
+var fromIndexerDiagramType = map[indexer.DiagramType]DiagramType{
+	indexer.TypeSequence:  DiagramTypeSequence,
+	indexer.TypeUsecase:   DiagramTypeUsecase,
+	indexer.TypeClass:     DiagramTypeClass,
+	indexer.TypeActivity:  DiagramTypeActivity,
+	indexer.TypeComponent: DiagramTypeComponent,
+	indexer.TypeState:     DiagramTypeState,
+	indexer.TypeUnknwon:   DiagramTypeUnknown,
+}
+
+var toIndexerDiagramType = map[DiagramType]indexer.DiagramType{
+	DiagramTypeSequence:  indexer.TypeSequence,
+	DiagramTypeUsecase:   indexer.TypeUsecase,
+	DiagramTypeClass:     indexer.TypeClass,
+	DiagramTypeActivity:  indexer.TypeActivity,
+	DiagramTypeComponent: indexer.TypeComponent,
+	DiagramTypeState:     indexer.TypeState,
+	DiagramTypeUnknown:   indexer.TypeUnknwon,
+}
+
+// FromIndexer converts an indexer.DiagramType to its GraphQL enum value,
+// falling back to DiagramTypeUnknown for anything we don't recognize.
+func FromIndexer(typ indexer.DiagramType) DiagramType {
+	if gql, ok := fromIndexerDiagramType[typ]; ok {
+		return gql
+	}
+	return DiagramTypeUnknown
+}
+
+// ToIndexer converts a GraphQL DiagramType back into the indexer's domain
+// type, used when building datastore filters from a UmlFilter input.
+func (t DiagramType) ToIndexer() indexer.DiagramType {
+	if idx, ok := toIndexerDiagramType[t]; ok {
+		return idx
+	}
+	return indexer.TypeUnknwon
+}
+
+func (t DiagramType) IsValid() bool {
+	_, ok := toIndexerDiagramType[t]
+	return ok
+}
+
+func (t DiagramType) String() string {
+	return string(t)
+}
+
+// Uml is the GraphQL-facing projection of indexer.Uml. Heavy render
+// artifacts (Svg, PngBase64, Ascii) are pointers so an un-hydrated field can
+// be represented as GraphQL null instead of an empty string. The graphql
+// package wraps this in its own tagged resolver type rather than exposing
+// it to graph-gophers/graphql-go directly, so this struct's field names
+// don't need to track the schema's GraphQL-casing rules.
+type Uml struct {
+	ID          string
+	GitHubUrl   string
+	Source      string
+	DiagramType DiagramType
+	Svg         *string
+	PngBase64   *string
+	Ascii       *string
+}
+
+// HydrateFields says which of a Uml's heavy render artifacts a caller
+// wants populated.
+type HydrateFields struct {
+	Svg       bool
+	PngBase64 bool
+	Ascii     bool
+}
+
+// UmlFilter narrows an Umls query to diagrams matching all of the set
+// fields.
+type UmlFilter struct {
+	DiagramType       *DiagramType
+	GitHubUrlContains *string
+	SourceContains    *string
+}
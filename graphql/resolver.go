@@ -0,0 +1,352 @@
+// Package graphql exposes the Uml entities produced by indexer.Indexer.Process
+// through a GraphQL API. schema.graphqls is parsed at process start by
+// graph-gophers/graphql-go, which dispatches each field to the matching Go
+// field/method via reflection — there is no codegen step, so edit
+// schema.graphqls and its resolver type together. Every resolver type below
+// tags its fields with `graphql:"..."` so field-name matching doesn't
+// depend on guessing the library's capitalization rules for things like
+// "id" or "gitHubUrl".
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/search"
+
+	"github.com/heshuaidi/real-world-plantuml/graphql/model"
+	"github.com/heshuaidi/real-world-plantuml/indexer"
+)
+
+// Resolver is the root struct graph-gophers/graphql-go dispatches the
+// schema's Query fields onto.
+type Resolver struct{}
+
+// UmlResolver is the resolver for the schema's Uml type.
+type UmlResolver struct {
+	ID          graphqlgo.ID      `graphql:"id"`
+	GitHubUrl   string            `graphql:"gitHubUrl"`
+	Source      string            `graphql:"source"`
+	DiagramType model.DiagramType `graphql:"diagramType"`
+	Svg         *string           `graphql:"svg"`
+	PngBase64   *string           `graphql:"pngBase64"`
+	Ascii       *string           `graphql:"ascii"`
+}
+
+func newUmlResolver(m *model.Uml) *UmlResolver {
+	if m == nil {
+		return nil
+	}
+	return &UmlResolver{
+		ID:          graphqlgo.ID(m.ID),
+		GitHubUrl:   m.GitHubUrl,
+		Source:      m.Source,
+		DiagramType: m.DiagramType,
+		Svg:         m.Svg,
+		PngBase64:   m.PngBase64,
+		Ascii:       m.Ascii,
+	}
+}
+
+// UmlEdgeResolver is the resolver for the schema's UmlEdge type.
+type UmlEdgeResolver struct {
+	Cursor string       `graphql:"cursor"`
+	Node   *UmlResolver `graphql:"node"`
+}
+
+// PageInfoResolver is the resolver for the schema's PageInfo type.
+type PageInfoResolver struct {
+	HasNextPage bool    `graphql:"hasNextPage"`
+	EndCursor   *string `graphql:"endCursor"`
+}
+
+// UmlConnectionResolver is the resolver for the schema's UmlConnection type.
+type UmlConnectionResolver struct {
+	Edges    []*UmlEdgeResolver `graphql:"edges"`
+	PageInfo *PageInfoResolver  `graphql:"pageInfo"`
+}
+
+// hydrateFieldsInput mirrors the schema's HydrateFields input object.
+type hydrateFieldsInput struct {
+	Svg       bool `graphql:"svg"`
+	PngBase64 bool `graphql:"pngBase64"`
+	Ascii     bool `graphql:"ascii"`
+}
+
+func (h *hydrateFieldsInput) toModel() model.HydrateFields {
+	if h == nil {
+		return model.HydrateFields{}
+	}
+	return model.HydrateFields{Svg: h.Svg, PngBase64: h.PngBase64, Ascii: h.Ascii}
+}
+
+// umlFilterInput mirrors the schema's UmlFilter input object.
+type umlFilterInput struct {
+	DiagramType       *model.DiagramType `graphql:"diagramType"`
+	GitHubUrlContains *string            `graphql:"gitHubUrlContains"`
+	SourceContains    *string            `graphql:"sourceContains"`
+}
+
+func (f *umlFilterInput) toModel() *model.UmlFilter {
+	if f == nil {
+		return nil
+	}
+	return &model.UmlFilter{
+		DiagramType:       f.DiagramType,
+		GitHubUrlContains: f.GitHubUrlContains,
+		SourceContains:    f.SourceContains,
+	}
+}
+
+type umlArgs struct {
+	ID      graphqlgo.ID        `graphql:"id"`
+	Hydrate *hydrateFieldsInput `graphql:"hydrate"`
+}
+
+// Uml resolves the `uml(id, hydrate)` root field. Lookups go through the
+// request's UmlLoader rather than a direct datastore.Get, so a query that
+// aliases this field N times still only costs one GetMulti.
+func (r *Resolver) Uml(ctx context.Context, args umlArgs) (*UmlResolver, error) {
+	intID, err := strconv.ParseInt(string(args.ID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uml id %q: %w", args.ID, err)
+	}
+	key := datastore.NewKey(ctx, "Uml", "", intID, nil)
+
+	loader := umlLoaderFromContext(ctx)
+	if loader == nil {
+		loader = NewUmlLoader(ctx)
+	}
+	uml, err := loader.Load(key)
+	if err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return newUmlResolver(toModel(key, uml, args.Hydrate.toModel())), nil
+}
+
+type umlsArgs struct {
+	Filter  *umlFilterInput     `graphql:"filter"`
+	Hydrate *hydrateFieldsInput `graphql:"hydrate"`
+	First   *int32              `graphql:"first"`
+	After   *string             `graphql:"after"`
+}
+
+// Umls resolves the `umls(filter, hydrate, first, after)` root field.
+//
+// The diagramType/source filters run against the uml_source FTS index, which
+// only ever matches whole tokens. gitHubUrlContains needs real substring
+// matching (e.g. "shuaidi" inside "heshuaidi"), which FTS can't do, so it
+// instead goes through the same trigram posting-list search
+// indexer.SearchBySubstring already does for identifiers; candidates are
+// then confirmed with strings.Contains against the real GitHubUrl to drop
+// any false positives from a shared trigram. When both kinds of filter are
+// present, the two candidate key sets are intersected before fetching.
+func (r *Resolver) Umls(ctx context.Context, args umlsArgs) (*UmlConnectionResolver, error) {
+	pageSize := 20
+	if args.First != nil {
+		pageSize = int(*args.First)
+	}
+	offset := 0
+	if args.After != nil {
+		o, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		offset = o
+	}
+
+	filter := args.Filter.toModel()
+
+	var keys []*datastore.Key
+	if filter != nil && filter.GitHubUrlContains != nil {
+		var err error
+		keys, err = umlsByGitHubUrlSubstring(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		keys, err = umlsByFTS(ctx, buildFTSQuery(filter))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortKeysByIntID(keys)
+
+	end := offset + pageSize
+	hasNextPage := len(keys) > end
+	if end > len(keys) {
+		end = len(keys)
+	}
+	if offset > len(keys) {
+		offset = len(keys)
+	}
+	page := keys[offset:end]
+
+	umls := make([]indexer.Uml, len(page))
+	if len(page) > 0 {
+		if err := datastore.GetMulti(ctx, page, umls); err != nil {
+			return nil, err
+		}
+	}
+
+	hf := args.Hydrate.toModel()
+	edges := make([]*UmlEdgeResolver, len(page))
+	for i, key := range page {
+		edges[i] = &UmlEdgeResolver{
+			Cursor: encodeCursor(offset + i + 1),
+			Node:   newUmlResolver(toModel(key, &umls[i], hf)),
+		}
+	}
+
+	pageInfo := &PageInfoResolver{HasNextPage: hasNextPage}
+	if len(edges) > 0 {
+		last := edges[len(edges)-1].Cursor
+		pageInfo.EndCursor = &last
+	}
+
+	return &UmlConnectionResolver{Edges: edges, PageInfo: pageInfo}, nil
+}
+
+// ftsCandidateLimit bounds how many uml_source hits umlsByFTS/
+// umlsByGitHubUrlSubstring will pull back before paginating in memory. It's
+// generous compared to any one page so filtered/intersected queries still
+// see the full candidate set, while keeping a single query bounded.
+const ftsCandidateLimit = 1000
+
+// umlsByFTS runs query (built by buildFTSQuery) against the uml_source FTS
+// index and returns every matching Uml key, up to ftsCandidateLimit.
+func umlsByFTS(ctx context.Context, query string) ([]*datastore.Key, error) {
+	fts, err := search.Open("uml_source")
+	if err != nil {
+		return nil, err
+	}
+
+	it := fts.Search(ctx, query, &search.SearchOptions{
+		Limit:   ftsCandidateLimit,
+		IDsOnly: true,
+	})
+
+	var keys []*datastore.Key
+	for {
+		id, err := it.Next(nil)
+		if err == search.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		intID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, datastore.NewKey(ctx, "Uml", "", intID, nil))
+	}
+	return keys, nil
+}
+
+// umlsByGitHubUrlSubstring resolves filter.GitHubUrlContains via the
+// gitHubUrl trigram posting lists, confirms each candidate against the real
+// GitHubUrl, and intersects with any diagramType/sourceContains filter run
+// through FTS.
+func umlsByGitHubUrlSubstring(ctx context.Context, filter *model.UmlFilter) ([]*datastore.Key, error) {
+	candidates, err := indexer.SearchByGitHubUrlSubstring(ctx, *filter.GitHubUrlContains)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	umls := make([]indexer.Uml, len(candidates))
+	if err := datastore.GetMulti(ctx, candidates, umls); err != nil {
+		return nil, err
+	}
+	needle := strings.ToLower(*filter.GitHubUrlContains)
+	keys := make([]*datastore.Key, 0, len(candidates))
+	for i, key := range candidates {
+		if strings.Contains(strings.ToLower(umls[i].GitHubUrl), needle) {
+			keys = append(keys, key)
+		}
+	}
+
+	if query := buildFTSQuery(&model.UmlFilter{DiagramType: filter.DiagramType, SourceContains: filter.SourceContains}); query != "" {
+		ftsKeys, err := umlsByFTS(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		allowed := make(map[string]bool, len(ftsKeys))
+		for _, k := range ftsKeys {
+			allowed[k.Encode()] = true
+		}
+		filtered := keys[:0]
+		for _, k := range keys {
+			if allowed[k.Encode()] {
+				filtered = append(filtered, k)
+			}
+		}
+		keys = filtered
+	}
+
+	return keys, nil
+}
+
+// sortKeysByIntID orders keys by their IntID so pagination over an
+// in-memory candidate set (built from trigram intersection rather than
+// FTS's own offset/limit) is stable across calls.
+func sortKeysByIntID(keys []*datastore.Key) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].IntID() < keys[j].IntID()
+	})
+}
+
+// buildFTSQuery translates a UmlFilter into the uml_source search query
+// syntax, ANDing together whichever of diagramType/source were supplied.
+// GitHubUrlContains is handled separately by umlsByGitHubUrlSubstring since
+// FTS can only match whole tokens, not substrings within one.
+func buildFTSQuery(filter *model.UmlFilter) string {
+	if filter == nil {
+		return ""
+	}
+	var clauses []string
+	if filter.DiagramType != nil {
+		clauses = append(clauses, fmt.Sprintf("diagramType:%s", filter.DiagramType.ToIndexer()))
+	}
+	if filter.SourceContains != nil {
+		clauses = append(clauses, "document:"+quoteFTSTerm(*filter.SourceContains))
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// quoteFTSTerm wraps a user-supplied term in double quotes and escapes any
+// embedded ones, matching the App Engine search query syntax.
+func quoteFTSTerm(term string) string {
+	return fmt.Sprintf("%q", term)
+}
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("offset:%d", offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var offset int
+	if _, err := fmt.Sscanf(string(raw), "offset:%d", &offset); err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return offset, nil
+}
@@ -0,0 +1,29 @@
+package graphql
+
+import (
+	_ "embed"
+	"net/http"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphqls
+var schemaString string
+
+var schema = graphqlgo.MustParseSchema(schemaString, &Resolver{}, graphqlgo.UseFieldResolvers())
+
+// NewHandler builds the http.Handler that serves the GraphQL endpoint,
+// wiring the hand-written Resolver into the schema parsed from
+// schema.graphqls. Every request gets its own UmlLoader attached to its
+// context before reaching relay.Handler, so resolvers can batch lookups
+// through umlLoaderFromContext.
+//
+// Callers mount it alongside the existing App Engine handlers, e.g.
+// http.Handle("/graphql", graphql.NewHandler()).
+func NewHandler() http.Handler {
+	relayHandler := &relay.Handler{Schema: schema}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		relayHandler.ServeHTTP(w, req.WithContext(withUmlLoader(req.Context())))
+	})
+}
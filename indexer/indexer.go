@@ -10,6 +10,8 @@ import (
 	"google.golang.org/appengine/datastore"
 	"google.golang.org/appengine/log"
 	"google.golang.org/appengine/search"
+
+	"github.com/heshuaidi/real-world-plantuml/renderer"
 )
 
 const (
@@ -19,7 +21,7 @@ const (
 type Indexer struct {
 	GitHubUrl     string
 	Content       string
-	Renderer      *Renderer
+	Renderer      *renderer.Chain
 	SyntaxChecker *SyntaxChecker
 	ctx           context.Context
 }
@@ -37,7 +39,10 @@ type Uml struct {
 type DiagramType string
 
 type FTSDocument struct {
-	Document string `search:"document"`
+	Document    string `search:"document"`
+	DiagramType string `search:"diagramType"`
+	Identifiers string `search:"identifiers"`
+	GitHubUrl   string `search:"gitHubUrl"`
 }
 
 const (
@@ -74,10 +79,10 @@ func guessDiagramType(source string, result *SyntaxCheckResult) DiagramType {
 	}
 }
 
-func NewIndexer(ctx context.Context, renderer *Renderer, syntaxChecker *SyntaxChecker, gitHubUrl string, content string) (*Indexer, error) {
+func NewIndexer(ctx context.Context, chain *renderer.Chain, syntaxChecker *SyntaxChecker, gitHubUrl string, content string) (*Indexer, error) {
 	return &Indexer{
 		Content:       content,
-		Renderer:      renderer,
+		Renderer:      chain,
 		SyntaxChecker: syntaxChecker,
 		GitHubUrl:     gitHubUrl,
 		ctx:           ctx,
@@ -109,7 +114,7 @@ func (idxr *Indexer) FindSources() []string {
 func (idxr *Indexer) Process() error {
 	ctx := idxr.ctx
 	sources := idxr.FindSources()
-	renderer := idxr.Renderer
+	rendererChain := idxr.Renderer
 	syntaxChecker := idxr.SyntaxChecker
 
 	fts, err := search.Open("uml_source")
@@ -146,6 +151,14 @@ func (idxr *Indexer) Process() error {
 				}
 			}
 		}
+
+		// Delete trigram entries
+		for _, key := range keys {
+			if err := deleteTrigrams(ctx, key); err != nil {
+				log.Criticalf(ctx, "failed to delete trigrams: %s", err)
+				return err
+			}
+		}
 	}
 
 	for _, source := range sources {
@@ -171,20 +184,20 @@ func (idxr *Indexer) Process() error {
 
 		typ := guessDiagramType(source, result)
 
-		svg, err := renderer.RenderSvg(source)
+		svg, err := rendererChain.RenderSvg(ctx, source)
 		if err != nil {
 			log.Criticalf(ctx, "failed to render svg: %s", err)
 			return err
 		}
 
-		png, err := renderer.RenderPng(source)
+		png, err := rendererChain.RenderPng(ctx, source)
 		if err != nil {
 			log.Criticalf(ctx, "failed to render png: %s", err)
 			return err
 		}
 		pngBase64 := base64.StdEncoding.EncodeToString(png)
 
-		ascii, err := renderer.RenderAscii(source)
+		ascii, err := rendererChain.RenderAscii(ctx, source)
 		if err != nil {
 			log.Criticalf(ctx, "failed to render ascii: %s", err)
 			return err
@@ -209,7 +222,10 @@ func (idxr *Indexer) Process() error {
 
 		// Register to full-text search index
 		doc := FTSDocument{
-			Document: source,
+			Document:    source,
+			DiagramType: string(typ),
+			Identifiers: strings.Join(extractIdentifiers(typ, source), " "),
+			GitHubUrl:   idxr.GitHubUrl,
 		}
 		_, err = fts.Put(ctx, fmt.Sprintf("%d", key.IntID()), &doc)
 		if err != nil {
@@ -217,6 +233,22 @@ func (idxr *Indexer) Process() error {
 			// Ignore error
 			continue
 		}
+
+		// Register trigrams for substring search over identifiers
+		if err := putTrigrams(ctx, key, extractIdentifiers(typ, source)); err != nil {
+			log.Criticalf(ctx, "failed to put trigrams: %s", err)
+			// Ignore error
+			continue
+		}
+
+		// Register trigrams for substring search over GitHubUrl, since FTS
+		// only tokenizes it and can't match a substring within a token
+		// (e.g. "shuaidi" inside "heshuaidi").
+		if err := putGitHubUrlTrigrams(ctx, key, idxr.GitHubUrl); err != nil {
+			log.Criticalf(ctx, "failed to put gitHubUrl trigrams: %s", err)
+			// Ignore error
+			continue
+		}
 	}
 
 	return nil
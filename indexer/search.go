@@ -0,0 +1,210 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/search"
+)
+
+// MatchLevel describes how much of a Uml attribute matched a search query,
+// so a frontend can decide how aggressively to highlight it.
+type MatchLevel string
+
+const (
+	MatchLevelFull    MatchLevel = "full"
+	MatchLevelPartial MatchLevel = "partial"
+	MatchLevelNone    MatchLevel = "none"
+)
+
+// Match annotates a single Uml attribute with which words of a search query
+// were found in it and where, so callers can wrap the hits in <mark> tags
+// without re-implementing the tokenizer.
+type Match struct {
+	Value        string
+	MatchLevel   MatchLevel
+	MatchedWords []string
+	Offsets      [][2]int
+}
+
+// SearchResult pairs a stored Uml with the highlighting metadata computed
+// for it.
+type SearchResult struct {
+	Key     *datastore.Key
+	Uml     Uml
+	Matches []Match
+}
+
+// SearchOptions controls a Search call. An empty SearchOptions performs an
+// unfiltered full-text query across every FTSDocument field.
+//
+// DiagramType narrows the query to the FTSDocument.DiagramType facet added
+// alongside highlighting, so a caller can combine "find me a sequence
+// diagram mentioning X" in one FTS round trip instead of fetching
+// everything and filtering client-side.
+type SearchOptions struct {
+	Limit       int
+	DiagramType DiagramType
+}
+
+// buildQuery ANDs the free-text query with any structured filters in opts,
+// using the facets Indexer.Process indexes alongside the raw document.
+func buildQuery(query string, opts SearchOptions) string {
+	clauses := make([]string, 0, 2)
+	if query != "" {
+		clauses = append(clauses, query)
+	}
+	if opts.DiagramType != "" {
+		clauses = append(clauses, fmt.Sprintf("diagramType:%s", opts.DiagramType))
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// diagramIdentifierKeywords are the UML keywords that precede an
+// identifier worth indexing, one slice per diagram type. Kept lightweight
+// (regex over keywords) rather than a full parse, matching how the rest of
+// this package favors simple string scanning over a real PlantUML parser.
+var diagramIdentifierKeywords = map[DiagramType][]string{
+	TypeSequence:  {"participant", "actor"},
+	TypeUsecase:   {"actor", "usecase"},
+	TypeClass:     {"class", "interface", "enum"},
+	TypeComponent: {"component", "interface"},
+	TypeState:     {"state"},
+}
+
+// extractIdentifiers pulls the names introduced by the diagram-specific
+// keywords above out of source, e.g. `participant UserService` yields
+// "UserService". Unrecognized diagram types fall back to no identifiers.
+func extractIdentifiers(typ DiagramType, source string) []string {
+	keywords, ok := diagramIdentifierKeywords[typ]
+	if !ok {
+		return nil
+	}
+
+	var identifiers []string
+	seen := make(map[string]bool)
+	for _, keyword := range keywords {
+		re := regexp.MustCompile(`\b` + keyword + `\s+"?([A-Za-z_][A-Za-z0-9_]*)"?`)
+		for _, m := range re.FindAllStringSubmatch(source, -1) {
+			name := m[1]
+			if !seen[name] {
+				seen[name] = true
+				identifiers = append(identifiers, name)
+			}
+		}
+	}
+	return identifiers
+}
+
+// tokenize splits a search query or a stored attribute into the words
+// MatchLevel/MatchedWords are computed over. It mirrors the identifier
+// pattern so that e.g. "UserService" tokenizes as one word, consistent
+// with how extractIdentifiers names things.
+func tokenize(s string) []string {
+	return identifierPattern.FindAllString(s, -1)
+}
+
+// highlight compares value against the tokenized query and returns the
+// Match describing how much of it matched, with byte offsets of every
+// matched token so a caller can wrap them in <mark> tags.
+func highlight(value string, queryTokens []string) Match {
+	queryWords := make(map[string]bool, len(queryTokens))
+	for _, t := range queryTokens {
+		queryWords[strings.ToLower(t)] = true
+	}
+
+	valueTokens := tokenize(value)
+	var matchedWords []string
+	matchedSet := make(map[string]bool)
+	var offsets [][2]int
+
+	searchFrom := 0
+	for _, word := range valueTokens {
+		idx := strings.Index(value[searchFrom:], word)
+		if idx == -1 {
+			continue
+		}
+		start := searchFrom + idx
+		searchFrom = start + len(word)
+
+		if queryWords[strings.ToLower(word)] {
+			if !matchedSet[word] {
+				matchedSet[word] = true
+				matchedWords = append(matchedWords, word)
+			}
+			offsets = append(offsets, [2]int{start, start + len(word)})
+		}
+	}
+
+	level := MatchLevelNone
+	switch {
+	case len(matchedWords) == 0:
+		level = MatchLevelNone
+	case len(matchedWords) == len(queryTokens):
+		level = MatchLevelFull
+	default:
+		level = MatchLevelPartial
+	}
+
+	return Match{
+		Value:        value,
+		MatchLevel:   level,
+		MatchedWords: matchedWords,
+		Offsets:      offsets,
+	}
+}
+
+// Search runs query against the uml_source FTS index and returns each hit's
+// Uml alongside a Match describing where the query's tokens were found in
+// its Source, so a frontend can highlight them.
+func Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	fts, err := search.Open("uml_source")
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	queryTokens := tokenize(query)
+
+	var results []SearchResult
+	it := fts.Search(ctx, buildQuery(query, opts), &search.SearchOptions{Limit: limit, IDsOnly: true})
+	for {
+		id, err := it.Next(nil)
+		if err == search.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// uml_source document IDs are the decimal Uml int64 key, set when
+		// the document is put in Indexer.Process.
+		var intID int64
+		if _, err := fmt.Sscanf(id, "%d", &intID); err != nil {
+			continue
+		}
+		key := datastore.NewKey(ctx, "Uml", "", intID, nil)
+
+		var uml Uml
+		if err := datastore.Get(ctx, key, &uml); err != nil {
+			return nil, err
+		}
+
+		results = append(results, SearchResult{
+			Key:     key,
+			Uml:     uml,
+			Matches: []Match{highlight(uml.Source, queryTokens)},
+		})
+	}
+
+	return results, nil
+}
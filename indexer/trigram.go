@@ -0,0 +1,173 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/appengine/datastore"
+)
+
+const trigramLength = 3
+
+// Trigram fields distinguish which value a UmlTrigram was derived from, so
+// SearchBySubstring and SearchByGitHubUrlSubstring don't cross-match each
+// other's posting lists (an identifier and the GitHubUrl happening to
+// share a trigram shouldn't make them candidates for each other's search).
+const (
+	trigramFieldIdentifier = "identifier"
+	trigramFieldGitHubUrl  = "gitHubUrl"
+)
+
+// UmlTrigram indexes a single 3-gram of either an identifier extracted
+// from a Uml's source (see extractIdentifiers) or its GitHubUrl, so
+// SearchBySubstring/SearchByGitHubUrlSubstring can find diagrams by
+// partial identifier or partial URL, e.g. "UserSer" matching
+// "UserService" or "shuaidi" matching ".../heshuaidi/...". Each entity is
+// a child of its Uml's key, so the owning Uml is recovered from the
+// entity key itself (key.Parent()) rather than a stored property, which
+// is what lets the search functions and deleteTrigrams stay keys-only
+// queries.
+type UmlTrigram struct {
+	Trigram string `datastore:"trigram"`
+	Field   string `datastore:"field"`
+	Value   string `datastore:"value,noindex"`
+}
+
+// trigrams returns every 3-gram contained in s, in order and with repeats,
+// lowercased so substring search is case-insensitive. Values shorter than a
+// trigram are skipped since they can't be decomposed.
+func trigrams(s string) []string {
+	s = strings.ToLower(s)
+	if len(s) < trigramLength {
+		return nil
+	}
+	grams := make([]string, 0, len(s)-trigramLength+1)
+	for i := 0; i+trigramLength <= len(s); i++ {
+		grams = append(grams, s[i:i+trigramLength])
+	}
+	return grams
+}
+
+// putTrigramsForField stores a UmlTrigram entity, parented under umlKey,
+// for every 3-gram of every value in values, tagged with field so it only
+// shows up in that field's posting lists.
+func putTrigramsForField(ctx context.Context, umlKey *datastore.Key, field string, values []string) error {
+	var entities []*UmlTrigram
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, trigram := range trigrams(value) {
+			dedupeKey := trigram + "\x00" + value
+			if seen[dedupeKey] {
+				continue
+			}
+			seen[dedupeKey] = true
+			entities = append(entities, &UmlTrigram{
+				Trigram: trigram,
+				Field:   field,
+				Value:   value,
+			})
+		}
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+
+	keys := make([]*datastore.Key, len(entities))
+	for i := range entities {
+		keys[i] = datastore.NewIncompleteKey(ctx, "UmlTrigram", umlKey)
+	}
+	_, err := datastore.PutMulti(ctx, keys, entities)
+	return err
+}
+
+// putTrigrams indexes the identifiers extracted from a diagram's source.
+func putTrigrams(ctx context.Context, umlKey *datastore.Key, identifiers []string) error {
+	return putTrigramsForField(ctx, umlKey, trigramFieldIdentifier, identifiers)
+}
+
+// putGitHubUrlTrigrams indexes a Uml's GitHubUrl so SearchByGitHubUrlSubstring
+// can find it by partial URL.
+func putGitHubUrlTrigrams(ctx context.Context, umlKey *datastore.Key, gitHubUrl string) error {
+	return putTrigramsForField(ctx, umlKey, trigramFieldGitHubUrl, []string{gitHubUrl})
+}
+
+// deleteTrigrams removes every UmlTrigram entity parented under umlKey,
+// regardless of which field it was indexed under.
+func deleteTrigrams(ctx context.Context, umlKey *datastore.Key) error {
+	q := datastore.NewQuery("UmlTrigram").Ancestor(umlKey).KeysOnly()
+	keys, err := q.GetAll(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return datastore.DeleteMulti(ctx, keys)
+}
+
+// searchTrigramsByField decomposes needle into trigrams and intersects
+// their posting lists, restricted to field, via keys-only UmlTrigram
+// queries, returning the distinct Uml keys that are candidates for
+// containing needle as a substring. Callers should verify candidates
+// against the real value (e.g. by re-fetching and checking
+// strings.Contains), since a shared trigram set doesn't guarantee the
+// substring occurs contiguously.
+func searchTrigramsByField(ctx context.Context, needle string, field string) ([]*datastore.Key, error) {
+	grams := trigrams(needle)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+
+	var candidates map[string]*datastore.Key
+	for i, trigram := range grams {
+		q := datastore.NewQuery("UmlTrigram").
+			Filter("trigram =", trigram).
+			Filter("field =", field).
+			KeysOnly()
+		keys, err := q.GetAll(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		posting := make(map[string]*datastore.Key, len(keys))
+		for _, key := range keys {
+			umlKey := key.Parent()
+			posting[umlKey.Encode()] = umlKey
+		}
+
+		if i == 0 {
+			candidates = posting
+			continue
+		}
+		for encoded := range candidates {
+			if _, ok := posting[encoded]; !ok {
+				delete(candidates, encoded)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+	}
+
+	result := make([]*datastore.Key, 0, len(candidates))
+	for _, key := range candidates {
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// SearchBySubstring finds Uml keys whose extracted identifiers are
+// candidates for containing needle as a substring, e.g. "UserSer"
+// matching "UserService".
+func SearchBySubstring(ctx context.Context, needle string) ([]*datastore.Key, error) {
+	return searchTrigramsByField(ctx, needle, trigramFieldIdentifier)
+}
+
+// SearchByGitHubUrlSubstring finds Uml keys whose GitHubUrl is a candidate
+// for containing needle as a substring, e.g. "shuaidi" matching
+// "https://github.com/heshuaidi/real-world-plantuml". As with
+// SearchBySubstring, candidates must still be verified against the real
+// GitHubUrl before being treated as confirmed matches.
+func SearchByGitHubUrlSubstring(ctx context.Context, needle string) ([]*datastore.Key, error) {
+	return searchTrigramsByField(ctx, needle, trigramFieldGitHubUrl)
+}
@@ -0,0 +1,26 @@
+// Package renderer lets the indexer render PlantUML sources through one of
+// several interchangeable backends (a local plantuml.jar subprocess, a
+// PlantUML HTTP server, Kroki, ...) instead of a single hard-coded
+// renderer, so the pipeline can run somewhere other than App Engine and
+// keep working when one backend goes down.
+package renderer
+
+import "context"
+
+// Format is the output format a Backend is asked to render.
+type Format string
+
+const (
+	FormatSvg   Format = "svg"
+	FormatPng   Format = "png"
+	FormatAscii Format = "ascii"
+)
+
+// Backend renders a single PlantUML source into the given format. Render
+// should return a non-nil error for anything the chain should treat as a
+// failure worth falling back on, including timeouts and non-2xx HTTP
+// responses.
+type Backend interface {
+	Name() string
+	Render(ctx context.Context, format Format, source string) ([]byte, error)
+}
@@ -0,0 +1,154 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Metrics is a snapshot of a single backend's observed behavior, used to
+// decide whether it should be skipped.
+type Metrics struct {
+	Requests       int
+	Errors         int
+	LastLatency    time.Duration
+	UnhealthyUntil time.Time
+}
+
+type chainEntry struct {
+	backend  Backend
+	sem      chan struct{} // nil means unlimited concurrency
+	cooldown time.Duration
+
+	mu sync.Mutex
+	// metrics is keyed by format rather than tracked once per backend, so
+	// a backend that permanently can't render one format (e.g. Kroki has
+	// no ascii output) only sits out of rotation for that format, not for
+	// every format it otherwise renders fine.
+	metrics map[Format]*Metrics
+}
+
+func (e *chainEntry) healthy(format Format) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m := e.metrics[format]
+	if m == nil {
+		return true
+	}
+	return time.Now().After(m.UnhealthyUntil)
+}
+
+func (e *chainEntry) record(format Format, latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m := e.metrics[format]
+	if m == nil {
+		m = &Metrics{}
+		e.metrics[format] = m
+	}
+	m.Requests++
+	m.LastLatency = latency
+	if err != nil {
+		m.Errors++
+		m.UnhealthyUntil = time.Now().Add(e.cooldown)
+	}
+}
+
+func (e *chainEntry) acquire() {
+	if e.sem != nil {
+		e.sem <- struct{}{}
+	}
+}
+
+func (e *chainEntry) release() {
+	if e.sem != nil {
+		<-e.sem
+	}
+}
+
+// Chain renders through a sequence of backends, trying each in order and
+// falling through to the next on error. A backend that errors sits out for
+// its configured cooldown window before the chain tries it again.
+type Chain struct {
+	entries []*chainEntry
+}
+
+// NewChain builds a Chain from backends in priority order. Every backend
+// gets the chain-wide cfg.Cooldown; per-backend concurrency limits come
+// from cfg.Backends, matched to backends by name, and a backend with no
+// matching config entry gets unlimited concurrency.
+func NewChain(cfg *Config, backends []Backend) *Chain {
+	byName := make(map[string]BackendConfig, len(cfg.Backends))
+	for _, bc := range cfg.Backends {
+		byName[bc.Name] = bc
+	}
+
+	entries := make([]*chainEntry, 0, len(backends))
+	for _, b := range backends {
+		bc := byName[b.Name()]
+		cooldown := cfg.Cooldown
+		var sem chan struct{}
+		if bc.MaxConcurrent > 0 {
+			sem = make(chan struct{}, bc.MaxConcurrent)
+		}
+		entries = append(entries, &chainEntry{backend: b, sem: sem, cooldown: cooldown, metrics: make(map[Format]*Metrics)})
+	}
+
+	return &Chain{entries: entries}
+}
+
+// Render tries each backend in order, skipping any currently in its
+// cooldown window, and returns the first successful result. If every
+// backend is unhealthy it returns a "no healthy backend" error; if every
+// backend it tried failed, it returns the last error seen.
+func (c *Chain) Render(ctx context.Context, format Format, source string) ([]byte, error) {
+	var lastErr error
+	tried := 0
+
+	for _, e := range c.entries {
+		if !e.healthy(format) {
+			continue
+		}
+		tried++
+
+		e.acquire()
+		start := time.Now()
+		result, err := e.backend.Render(ctx, format, source)
+		e.record(format, time.Since(start), err)
+		e.release()
+
+		if err != nil {
+			log.Printf("renderer: backend %s failed: %v", e.backend.Name(), err)
+			lastErr = err
+			continue
+		}
+		return result, nil
+	}
+
+	if tried == 0 {
+		return nil, fmt.Errorf("renderer: no healthy backend available")
+	}
+	return nil, fmt.Errorf("renderer: all backends failed: %w", lastErr)
+}
+
+// RenderSvg renders source to SVG, returned as a string to match the Uml
+// entity's Svg field.
+func (c *Chain) RenderSvg(ctx context.Context, source string) (string, error) {
+	b, err := c.Render(ctx, FormatSvg, source)
+	return string(b), err
+}
+
+// RenderPng renders source to PNG bytes, left undecoded so the caller can
+// base64-encode it the same way the single-backend Renderer used to.
+func (c *Chain) RenderPng(ctx context.Context, source string) ([]byte, error) {
+	return c.Render(ctx, FormatPng, source)
+}
+
+// RenderAscii renders source to ASCII art, returned as a string to match
+// the Uml entity's Ascii field.
+func (c *Chain) RenderAscii(ctx context.Context, source string) (string, error) {
+	b, err := c.Render(ctx, FormatAscii, source)
+	return string(b), err
+}
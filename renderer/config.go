@@ -0,0 +1,94 @@
+package renderer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// BackendConfig describes one entry in the chain. Type names the Backend
+// implementation the entry is for. This package has no constructor that
+// builds a Backend from a BackendConfig; NewChain reads only Name and
+// MaxConcurrent from it.
+type BackendConfig struct {
+	Name          string            `yaml:"name"`
+	Type          string            `yaml:"type"` // "local", "http", "kroki"
+	Endpoint      string            `yaml:"endpoint"`
+	Timeout       time.Duration     `yaml:"timeout"`
+	Headers       map[string]string `yaml:"headers"`
+	MaxConcurrent int               `yaml:"maxConcurrent"`
+}
+
+// Config is the chain's configuration: which backends to try, in order,
+// and how long an unhealthy backend sits out before being retried.
+type Config struct {
+	Backends []BackendConfig `yaml:"backends"`
+	Cooldown time.Duration   `yaml:"cooldown"`
+}
+
+// LoadConfig reads a chain config from a YAML file and then applies
+// environment overrides, so operators can point a backend at a different
+// endpoint (or add an auth header) without touching the checked-in file.
+// Overrides are named RENDERER_<BACKEND_NAME>_<FIELD>, e.g.
+// RENDERER_KROKI_ENDPOINT or RENDERER_KROKI_TIMEOUT, with the backend name
+// upper-cased and non-alphanumeric characters replaced with underscores.
+func LoadConfig(path string) (*Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("renderer: failed to read config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("renderer: failed to parse config %s: %w", path, err)
+	}
+
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+
+	for i := range cfg.Backends {
+		applyEnvOverrides(&cfg.Backends[i])
+	}
+
+	return &cfg, nil
+}
+
+func applyEnvOverrides(b *BackendConfig) {
+	prefix := "RENDERER_" + envKey(b.Name) + "_"
+
+	if v := os.Getenv(prefix + "ENDPOINT"); v != "" {
+		b.Endpoint = v
+	}
+	if v := os.Getenv(prefix + "TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			b.Timeout = d
+		}
+	}
+	if v := os.Getenv(prefix + "MAX_CONCURRENT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			b.MaxConcurrent = n
+		}
+	}
+	if v := os.Getenv(prefix + "AUTH_HEADER"); v != "" {
+		if b.Headers == nil {
+			b.Headers = make(map[string]string)
+		}
+		b.Headers["Authorization"] = v
+	}
+}
+
+func envKey(name string) string {
+	upper := strings.ToUpper(name)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, upper)
+}
@@ -0,0 +1,97 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPBackend talks to a PlantUML server (https://github.com/plantuml/plantuml-server)
+// exposed over HTTP, POSTing the raw source to /svg, /png or /txt (for
+// ascii) and returning the rendered body.
+type HTTPBackend struct {
+	BackendName string
+	Endpoint    string
+	Headers     map[string]string
+	Timeout     time.Duration
+	client      *http.Client
+	clientOnce  sync.Once
+}
+
+func (b *HTTPBackend) Name() string {
+	if b.BackendName != "" {
+		return b.BackendName
+	}
+	return "http"
+}
+
+func (b *HTTPBackend) Render(ctx context.Context, format Format, source string) ([]byte, error) {
+	path, err := httpFormatPath(format)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout())
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, b.Endpoint+path, bytes.NewBufferString(source))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build request: %w", b.Name(), err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "text/plain")
+	for k, v := range b.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := b.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: request failed: %w", b.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read response: %w", b.Name(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: server returned %s: %s", b.Name(), resp.Status, body)
+	}
+	return body, nil
+}
+
+func (b *HTTPBackend) timeout() time.Duration {
+	if b.Timeout > 0 {
+		return b.Timeout
+	}
+	return 10 * time.Second
+}
+
+// httpClient returns the backend's http.Client, building a default one on
+// first use. clientOnce makes that lazy init race-free under concurrent
+// Render calls, which the chain's MaxConcurrent setting explicitly allows.
+func (b *HTTPBackend) httpClient() *http.Client {
+	b.clientOnce.Do(func() {
+		if b.client == nil {
+			b.client = &http.Client{}
+		}
+	})
+	return b.client
+}
+
+func httpFormatPath(format Format) (string, error) {
+	switch format {
+	case FormatSvg:
+		return "/svg", nil
+	case FormatPng:
+		return "/png", nil
+	case FormatAscii:
+		return "/txt", nil
+	default:
+		return "", fmt.Errorf("http: unsupported format %q", format)
+	}
+}
@@ -0,0 +1,90 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// KrokiBackend renders through a Kroki instance (https://kroki.io or a
+// self-hosted one), POSTing the raw source to /plantuml/{format}. Kroki
+// has no ascii-art output, so Render returns an error for FormatAscii and
+// relies on the chain falling through to the next backend.
+type KrokiBackend struct {
+	Endpoint   string
+	Headers    map[string]string
+	Timeout    time.Duration
+	client     *http.Client
+	clientOnce sync.Once
+}
+
+func (b *KrokiBackend) Name() string { return "kroki" }
+
+func (b *KrokiBackend) Render(ctx context.Context, format Format, source string) ([]byte, error) {
+	path, err := krokiFormatPath(format)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout())
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, b.Endpoint+path, bytes.NewBufferString(source))
+	if err != nil {
+		return nil, fmt.Errorf("kroki: failed to build request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "text/plain")
+	for k, v := range b.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := b.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kroki: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("kroki: failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kroki: server returned %s: %s", resp.Status, body)
+	}
+	return body, nil
+}
+
+func (b *KrokiBackend) timeout() time.Duration {
+	if b.Timeout > 0 {
+		return b.Timeout
+	}
+	return 10 * time.Second
+}
+
+// httpClient returns the backend's http.Client, building a default one on
+// first use. clientOnce makes that lazy init race-free under concurrent
+// Render calls, which the chain's MaxConcurrent setting explicitly allows.
+func (b *KrokiBackend) httpClient() *http.Client {
+	b.clientOnce.Do(func() {
+		if b.client == nil {
+			b.client = &http.Client{}
+		}
+	})
+	return b.client
+}
+
+func krokiFormatPath(format Format) (string, error) {
+	switch format {
+	case FormatSvg:
+		return "/plantuml/svg", nil
+	case FormatPng:
+		return "/plantuml/png", nil
+	default:
+		return "", fmt.Errorf("kroki: unsupported format %q", format)
+	}
+}
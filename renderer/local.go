@@ -0,0 +1,54 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// LocalBackend shells out to a local plantuml.jar, the renderer this
+// package's other backends are meant to be a fallback chain around.
+type LocalBackend struct {
+	JarPath string
+	JavaBin string // defaults to "java" if empty
+}
+
+func (b *LocalBackend) Name() string { return "local" }
+
+func (b *LocalBackend) Render(ctx context.Context, format Format, source string) ([]byte, error) {
+	javaBin := b.JavaBin
+	if javaBin == "" {
+		javaBin = "java"
+	}
+
+	flag, err := localFormatFlag(format)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, javaBin, "-jar", b.JarPath, flag, "-pipe")
+	cmd.Stdin = bytes.NewBufferString(source)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("local: plantuml.jar failed: %w: %s", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func localFormatFlag(format Format) (string, error) {
+	switch format {
+	case FormatSvg:
+		return "-tsvg", nil
+	case FormatPng:
+		return "-tpng", nil
+	case FormatAscii:
+		return "-tutxt", nil
+	default:
+		return "", fmt.Errorf("local: unsupported format %q", format)
+	}
+}